@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// bookmarkFile records, per source file, the ID of the last measurement
+// whose bulk commit has been confirmed by Elasticsearch. With
+// --resume-from, a re-run of the loader skips rows up to that point
+// instead of relying on OpType "create" collisions to make re-ingestion
+// a no-op.
+const bookmarkFile = "bookmarks.json"
+
+// Bookmarks maps a source file path to the ID of the last measurement
+// confirmed indexed from it.
+type Bookmarks map[string]string
+
+// loadBookmarks reads bookmarkFile, returning an empty set if it doesn't
+// exist yet.
+func loadBookmarks() (Bookmarks, error) {
+	raw, err := ioutil.ReadFile(bookmarkFile)
+	if os.IsNotExist(err) {
+		return Bookmarks{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	bookmarks := Bookmarks{}
+	if err := json.Unmarshal(raw, &bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// save persists the bookmarks to bookmarkFile.
+func (b Bookmarks) save() error {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(bookmarkFile, raw, 0644)
+}
+
+// pendingEntry is a measurement handed to the bulk processor whose
+// commit hasn't been confirmed yet.
+type pendingEntry struct {
+	file string
+	id   string
+}
+
+var (
+	pendingMu sync.Mutex
+	pending   []pendingEntry
+	resolved  = map[string]bool{} // measurement ID -> commit succeeded
+)
+
+// trackPending records a measurement as queued for a given source file.
+// Its bookmark only advances once resolvePending reports the commit
+// that carries it as confirmed, so a row that ends up in the dead-letter
+// file is never skipped by a later --resume-from run.
+func trackPending(file, id string) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	pending = append(pending, pendingEntry{file: file, id: id})
+}
+
+// resolvePending marks measurement IDs as committed (succeeded true) or
+// permanently failed (succeeded false), then drains the longest confirmed
+// prefix of the pending queue, which holds the rows of every source file
+// in the order they were queued, advances each drained file's bookmark to
+// its last drained row, and persists it. A failed entry is a stop barrier:
+// no bookmark advances past it, neither for later entries of the same
+// file nor for entries of files queued after it, even if they already
+// succeeded, so a dead-lettered row is never skipped by a later
+// --resume-from run. It is safe to call concurrently from the bulk
+// processor's After callback.
+func resolvePending(ids []string, succeeded bool) {
+	if len(ids) == 0 {
+		return
+	}
+
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	for _, id := range ids {
+		resolved[id] = succeeded
+	}
+
+	advanced := Bookmarks{}
+	for len(pending) > 0 {
+		entry := pending[0]
+		ok, done := resolved[entry.id]
+		if !done || !ok {
+			break
+		}
+		advanced[entry.file] = entry.id
+		delete(resolved, entry.id)
+		pending = pending[1:]
+	}
+
+	if len(advanced) == 0 {
+		return
+	}
+
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		logger.Error("Loading bookmarks", zap.String("error", err.Error()))
+		return
+	}
+	for file, id := range advanced {
+		bookmarks[file] = id
+	}
+	if err := bookmarks.save(); err != nil {
+		logger.Error("Saving bookmarks", zap.String("error", err.Error()))
+	}
+}
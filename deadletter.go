@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/olivere/elastic"
+	"go.uber.org/zap"
+)
+
+// deadLetterFile collects bulk requests the cluster never accepted, so
+// they can be inspected instead of silently dropped.
+const deadLetterFile = "deadletter.ndjson"
+
+// deadLetterRecord is one line of deadLetterFile.
+type deadLetterRecord struct {
+	Error   string   `json:"error"`
+	Request []string `json:"request,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Index   string   `json:"index,omitempty"`
+}
+
+// writeDeadLetter appends failed bulk requests to deadLetterFile with
+// the Elasticsearch error that rejected them. Either bulkErr (the whole
+// commit failed after the BulkProcessor's backoff was exhausted) or
+// response.Failed() (a partial failure) is populated, never both. For a
+// whole-commit failure each record holds the request's source lines; for
+// a partial failure each record holds only the rejected item's ID, index
+// and error, not the request payload.
+func writeDeadLetter(requests []elastic.BulkableRequest, response *elastic.BulkResponse, bulkErr error) {
+	file, err := os.OpenFile(deadLetterFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("Opening dead-letter file",
+			zap.String("error", err.Error()))
+		return
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	if bulkErr != nil {
+		for _, req := range requests {
+			lines, err := req.Source()
+			if err != nil {
+				logger.Error("Reading source of failed bulk request",
+					zap.String("error", err.Error()))
+				continue
+			}
+			writeDeadLetterRecord(writer, deadLetterRecord{Error: bulkErr.Error(), Request: lines})
+		}
+		return
+	}
+
+	if response == nil {
+		return
+	}
+	for _, failed := range response.Failed() {
+		record := deadLetterRecord{ID: failed.Id, Index: failed.Index}
+		if failed.Error != nil {
+			record.Error = failed.Error.Reason
+		}
+		writeDeadLetterRecord(writer, record)
+	}
+}
+
+func writeDeadLetterRecord(writer *bufio.Writer, record deadLetterRecord) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("Marshalling dead-letter record",
+			zap.String("error", err.Error()))
+		return
+	}
+	writer.Write(raw)
+	writer.WriteString("\n")
+}
+
+// bulkActionMeta is the "_id" a bulk request's action/metadata line
+// carries for either a "create" or "index" op.
+type bulkActionMeta struct {
+	ID string `json:"_id"`
+}
+
+// bulkActionLine is the first line of a BulkableRequest's Source().
+type bulkActionLine struct {
+	Create *bulkActionMeta `json:"create"`
+	Index  *bulkActionMeta `json:"index"`
+}
+
+// bulkRequestIDs extracts the measurement ID of each request, so a
+// whole-commit failure (no per-item BulkResponse to read Id from) can
+// still be reported to resolvePending.
+func bulkRequestIDs(requests []elastic.BulkableRequest) []string {
+	ids := make([]string, 0, len(requests))
+	for _, req := range requests {
+		lines, err := req.Source()
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		var action bulkActionLine
+		if err := json.Unmarshal([]byte(lines[0]), &action); err != nil {
+			continue
+		}
+		switch {
+		case action.Create != nil && action.Create.ID != "":
+			ids = append(ids, action.Create.ID)
+		case action.Index != nil && action.Index.ID != "":
+			ids = append(ids, action.Index.ID)
+		}
+	}
+	return ids
+}
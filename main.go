@@ -1,49 +1,79 @@
 package main
 
 import (
-	"bufio"
 	"context"
-	"encoding/csv"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"strconv"
+	"runtime"
 	"time"
 
 	"github.com/olivere/elastic"
 	"go.uber.org/zap"
+
+	"github.com/harsh543/Chicago-Weather-using-ELK/sources"
 )
 
 var (
 	logger *zap.Logger
 )
 
-type WeatherMeasurement struct {
-	ID                         string  `json:"id"`
-	Timestamp                  string  `json:"date"`
-	TemperatureCelsius         float32 `json:"temperature_celsius"`
-	TemperatureFahrenheit      int32   `json:"temperature_fahrenheit"`
-	HumidityPercentage         int32   `json:"humidity_percentage"`
-	RainIntensityMMPerHour     float32 `json:"rain_intensity_mm_per_hour"`
-	RainIntensityInchesPerHour float32 `json:"rain_intensity_inches_per_hour"`
-	RainSinceLastHourMM        float32 `json:"rain_since_last_hour_mm"`
-	RainSinceLastHourInches    float32 `json:"rain_since_last_hour_inches"`
-	PrecipitationType          int32   `json:"precipitation_type"`
-}
-
-// checkforErrors is invoked by bulk processor after every commit.
-// The err variable indicates success or failure.
+// checkForErrors is invoked by the bulk processor after every commit. A
+// non-nil err means the commit failed even after the processor's backoff
+// was exhausted; a response with Errors set means some individual items
+// were rejected while the rest succeeded. Either way the failure is
+// written to deadLetterFile rather than aborting the loader (see
+// writeDeadLetter for what each record holds).
+// This is also the only place a measurement's bookmark is allowed to
+// advance, since it's the first point a commit is actually confirmed.
 func checkForErrors(executionId int64,
 	requests []elastic.BulkableRequest,
 	response *elastic.BulkResponse,
 	err error) {
 	if err != nil {
-		logger.Error("Bulk Insert Error",
+		logger.Error("Bulk commit failed, writing to dead-letter file",
 			zap.String("error", err.Error()))
-		os.Exit(1)
+		writeDeadLetter(requests, nil, err)
+		resolvePending(bulkRequestIDs(requests), false)
+		return
+	}
+	if response == nil {
+		return
+	}
+	if response.Errors {
+		logger.Warn("Bulk commit had rejected items, writing to dead-letter file",
+			zap.Int("failed", len(response.Failed())))
+		writeDeadLetter(nil, response, nil)
+	}
+
+	succeeded := response.Succeeded()
+	succeededIDs := make([]string, 0, len(succeeded))
+	for _, item := range succeeded {
+		succeededIDs = append(succeededIDs, item.Id)
+	}
+	resolvePending(succeededIDs, true)
+
+	failed := response.Failed()
+	failedIDs := make([]string, 0, len(failed))
+	for _, item := range failed {
+		failedIDs = append(failedIDs, item.Id)
+	}
+	resolvePending(failedIDs, false)
+}
+
+// openSource builds the WeatherSource selected by --source. csv is not
+// handled here: it always runs through runCSVPipeline, which needs the
+// concrete *sources.CSVSource rather than the WeatherSource interface.
+func openSource(sourceKind, weatherFile string) (sources.WeatherSource, error) {
+	switch sourceKind {
+	case "ndjson":
+		return sources.NewNDJSONSource(weatherFile)
+	case "owm":
+		return sources.NewOWMSource(weatherFile)
+	default:
+		return nil, fmt.Errorf("unknown source %q", sourceKind)
 	}
 }
 
@@ -53,6 +83,15 @@ func main() {
 	logger.Info("Elastic loader ... starting")
 
 	deleteFlag := flag.Bool("delete", false, "Delete index")
+	serveFlag := flag.Bool("serve", false, "Run the HTTP ingestion API and SODA poller instead of exiting after the CSV import")
+	serveAddr := flag.String("addr", ":8080", "Address for the HTTP ingestion API to listen on")
+	sodaEndpoint := flag.String("soda-endpoint", "", "Chicago Data Portal SODA API endpoint to poll for new station readings")
+	sourceKind := flag.String("source", "csv", "Weather source type: csv, ndjson, or owm")
+	schemaName := flag.String("schema", "oak", "CSV column schema to use when --source=csv: oak or beach")
+	resumeFlag := flag.Bool("resume-from", false, "Skip rows already ingested according to bookmarkFile")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of parallel CSV parser workers")
+	dryRunFlag := flag.Bool("dry-run", false, "Run the full CSV pipeline but skip indexing, for benchmarking parse throughput")
+	metricsAddr := flag.String("metrics-addr", "", "Address to serve Prometheus-style /metrics on; empty disables it")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -132,13 +171,44 @@ func main() {
 			zap.String("index", indexName))
 	}
 
-	// Bulk Processor
+	// Bulk Processor. BulkSize/FlushInterval bound how much is buffered
+	// before a commit, and Backoff retries a failing commit with
+	// exponential delay before checkForErrors gives up on it.
 	bulkProc, err := elasticClient.
 		BulkProcessor().
 		Name("Worker").
 		Workers(4).
+		BulkActions(1000).
+		BulkSize(5 << 20).
+		FlushInterval(10 * time.Second).
+		Backoff(elastic.NewExponentialBackoff(100*time.Millisecond, 8*time.Second)).
 		After(checkForErrors).
 		Do(context.Background())
+	if err != nil {
+		logger.Error("Error creating Bulk Processor",
+			zap.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Station metadata, so every indexed measurement can carry a geo_point
+	stations, err := sources.LoadStations()
+	if err != nil {
+		logger.Error("Loading station metadata",
+			zap.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Bookmarks, so --resume-from can skip rows whose commit a previous run
+	// already confirmed instead of relying on OpType "create" collisions.
+	bookmarks := Bookmarks{}
+	if *resumeFlag {
+		bookmarks, err = loadBookmarks()
+		if err != nil {
+			logger.Error("Loading bookmarks",
+				zap.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
 
 	// Weather Measurements
 	weatherFiles := []string{
@@ -147,109 +217,82 @@ func main() {
 	}
 
 	for _, weatherFile := range weatherFiles {
-		logger.Info("Parsing file", zap.String("name", weatherFile))
-		csvFile, _ := os.Open(weatherFile)
-		reader := csv.NewReader(bufio.NewReader(csvFile))
+		logger.Info("Parsing file",
+			zap.String("name", weatherFile),
+			zap.String("source", *sourceKind))
 
-		for {
-			line, err := reader.Read()
-			if err == io.EOF {
-				break
-			} else if err != nil {
-				logger.Error("Reading Trip CSV file",
-					zap.String("error", err.Error()))
-				os.Exit(1)
-			}
-			if line[0] == "Station Name" {
-				continue
-			}
-			measurement := WeatherMeasurement{}
-			measurement.ID = line[17]
-
-			// Convert Measurement Timestamp from CST to ISO-8601 format
-			loc, err := time.LoadLocation("America/Chicago")
+		if *sourceKind == "csv" {
+			schema, err := sources.LoadSchema(*schemaName)
 			if err != nil {
-				logger.Error("Error getting timezone of America/Chicago",
+				logger.Error("Loading CSV schema",
+					zap.String("name", weatherFile),
 					zap.String("error", err.Error()))
 				os.Exit(1)
 			}
-			isoTime := fmt.Sprintf("%s %s", line[1], time.Now().In(loc).Format("-0700 MST"))
-			parsedTime, err := time.Parse("01/02/2006 03:04:05 PM -0700 MST", isoTime)
+			csvSource, err := sources.NewCSVSource(weatherFile, schema, stations)
 			if err != nil {
-				logger.Error("Error parsing StartTime into Time",
+				logger.Error("Opening weather source",
+					zap.String("name", weatherFile),
 					zap.String("error", err.Error()))
 				os.Exit(1)
 			}
-			measurement.Timestamp = parsedTime.Format(time.RFC3339)
 
-			// Convert Temperature from String to Float
-			temp, err := strconv.ParseFloat(line[2], 64)
+			err = runCSVPipeline(ctx, csvSource, bulkProc, indexName, *workers, *dryRunFlag, *metricsAddr, weatherFile, bookmarks[weatherFile])
 			if err != nil {
-				logger.Error("Converting Air Temperature to float",
-					zap.String("id", line[2]),
+				logger.Error("Running CSV pipeline",
+					zap.String("name", weatherFile),
 					zap.String("error", err.Error()))
 				os.Exit(1)
 			}
-			measurement.TemperatureCelsius = float32(temp)
-			measurement.TemperatureFahrenheit = int32(temp*1.8 + 32.0)
-
-			// Convert Humidity from String to Int32
-			humidity, err := strconv.Atoi(line[4])
-			if err != nil {
-				logger.Error("Converting Humidity to int",
-					zap.String("id", line[4]),
+			if err := csvSource.Close(); err != nil {
+				logger.Error("Closing weather source",
+					zap.String("name", weatherFile),
 					zap.String("error", err.Error()))
-				os.Exit(1)
 			}
-			measurement.HumidityPercentage = int32(humidity)
+			continue
+		}
 
-			// Convert Rain Intensity from String to Float
-			ri, err := strconv.ParseFloat(line[5], 64)
-			if err != nil {
-				logger.Error("Converting Rain Intensity to float",
-					zap.String("id", line[5]),
-					zap.String("error", err.Error()))
-				os.Exit(1)
-			}
-			measurement.RainIntensityMMPerHour = float32(ri)
-			measurement.RainIntensityInchesPerHour = float32(ri * 0.0393701)
+		source, err := openSource(*sourceKind, weatherFile)
+		if err != nil {
+			logger.Error("Opening weather source",
+				zap.String("name", weatherFile),
+				zap.String("error", err.Error()))
+			os.Exit(1)
+		}
 
-			// Convert Interval Rain from String to Float
-			ir, err := strconv.ParseFloat(line[6], 64)
-			if err != nil {
-				logger.Error("Converting Interval Rain to float",
-					zap.String("id", line[6]),
+		skipUntil := bookmarks[weatherFile]
+
+		for {
+			measurement, err := source.Next(ctx)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				logger.Error("Reading weather source",
+					zap.String("name", weatherFile),
 					zap.String("error", err.Error()))
 				os.Exit(1)
 			}
-			measurement.RainSinceLastHourMM = float32(ir)
-			measurement.RainSinceLastHourInches = float32(ir * 0.0393701)
 
-			// Convert Precipitation Type from String to Int32
-			pType, err := strconv.Atoi(line[8])
-			if err != nil {
-				logger.Error("Converting Precipitation Type to int",
-					zap.String("id", line[8]),
-					zap.String("error", err.Error()))
-				os.Exit(1)
+			if skipUntil != "" {
+				if measurement.ID == skipUntil {
+					skipUntil = ""
+				}
+				continue
 			}
-			measurement.PrecipitationType = int32(pType)
 
-			// Marshall the Weather Measurement into JSON and add to queue for Bulk API
-			jsonM, err := json.Marshal(measurement)
-			if err != nil {
+			if err := indexMeasurement(bulkProc, indexName, measurement); err != nil {
 				logger.Error("Error marshalling JSON",
 					zap.String("error", err.Error()))
 				os.Exit(1)
-			} else {
-				indexRequest := elastic.NewBulkIndexRequest().
-					Index(indexName).
-					Type("_doc").
-					OpType("create").
-					Id(measurement.ID).
-					Doc(string(jsonM))
-				bulkProc.Add(indexRequest)
 			}
+
+			trackPending(weatherFile, measurement.ID)
+		}
+
+		if err := source.Close(); err != nil {
+			logger.Error("Closing weather source",
+				zap.String("name", weatherFile),
+				zap.String("error", err.Error()))
 		}
 	}
 
@@ -259,4 +302,10 @@ func main() {
 			zap.String("error", err.Error()))
 		os.Exit(1)
 	}
+
+	if *serveFlag {
+		logger.Info("CSV import complete, switching to live ingestion")
+		go startSodaPoller(ctx, *sodaEndpoint, bulkProc, indexName)
+		startIngestServer(ctx, *serveAddr, bulkProc, indexName)
+	}
 }
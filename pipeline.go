@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/olivere/elastic"
+	"go.uber.org/zap"
+
+	"github.com/harsh543/Chicago-Weather-using-ELK/sources"
+)
+
+// rowQueueDepth bounds how many unparsed rows may sit between the reader
+// and the parser workers.
+const rowQueueDepth = 1000
+
+// pipelineMetrics tracks throughput for the parallel CSV pipeline so it
+// can be logged periodically and served over pipelineMetrics.handler.
+type pipelineMetrics struct {
+	rowsProcessed uint64
+	parseErrors   uint64
+	rowQueue      chan []string
+}
+
+func (m *pipelineMetrics) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# HELP chicago_weather_rows_processed_total Rows successfully parsed, and queued for indexing unless in dry-run mode\n")
+		fmt.Fprintf(w, "# TYPE chicago_weather_rows_processed_total counter\n")
+		fmt.Fprintf(w, "chicago_weather_rows_processed_total %d\n", atomic.LoadUint64(&m.rowsProcessed))
+		fmt.Fprintf(w, "# HELP chicago_weather_parse_errors_total Rows that failed to parse\n")
+		fmt.Fprintf(w, "# TYPE chicago_weather_parse_errors_total counter\n")
+		fmt.Fprintf(w, "chicago_weather_parse_errors_total %d\n", atomic.LoadUint64(&m.parseErrors))
+		fmt.Fprintf(w, "# HELP chicago_weather_row_queue_depth Rows read but not yet parsed\n")
+		fmt.Fprintf(w, "# TYPE chicago_weather_row_queue_depth gauge\n")
+		fmt.Fprintf(w, "chicago_weather_row_queue_depth %d\n", len(m.rowQueue))
+	}
+}
+
+// reportMetrics logs throughput once per interval until ctx is done.
+func reportMetrics(ctx context.Context, metrics *pipelineMetrics, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastRows uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rows := atomic.LoadUint64(&metrics.rowsProcessed)
+			logger.Info("CSV pipeline throughput",
+				zap.Uint64("rows_per_interval", rows-lastRows),
+				zap.Duration("interval", interval),
+				zap.Uint64("rows_total", rows),
+				zap.Uint64("parse_errors_total", atomic.LoadUint64(&metrics.parseErrors)),
+				zap.Int("queue_depth", len(metrics.rowQueue)))
+			lastRows = rows
+		}
+	}
+}
+
+// runCSVPipeline drives the fan-out/fan-in CSV import: a single reader
+// goroutine feeds raw rows to a buffered channel, workers parse rows and
+// submit them to the bulk processor concurrently, and a metrics
+// goroutine reports throughput for the duration of the run. With dryRun
+// set, parsed measurements are counted but never queued for indexing, so
+// the pipeline's parse throughput can be benchmarked on its own.
+//
+// skipUntil, if non-empty, makes the reader goroutine discard rows up to
+// and including the one with that ID before any row reaches the parser
+// workers, mirroring --resume-from for the sequential path.
+//
+// fileName identifies the source file to trackPending, so fileName's
+// bookmark only advances once checkForErrors confirms a row's commit,
+// not merely once a worker has queued it. trackPending is called from
+// the single reader goroutine, in file order, rather than from the
+// workers: workers finish in whatever order the bulk commits land in,
+// and resolvePending's prefix-draining logic only produces a valid
+// resume point if pending is queued in file order.
+func runCSVPipeline(ctx context.Context, source *sources.CSVSource, bulkProc *elastic.BulkProcessor, indexName string, workers int, dryRun bool, metricsAddr string, fileName string, skipUntil string) error {
+	metrics := &pipelineMetrics{rowQueue: make(chan []string, rowQueueDepth)}
+
+	metricsCtx, stopMetrics := context.WithCancel(ctx)
+	defer stopMetrics()
+	go reportMetrics(metricsCtx, metrics, 5*time.Second)
+
+	if metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.handler())
+		server := &http.Server{Addr: metricsAddr, Handler: mux}
+		go func() {
+			<-metricsCtx.Done()
+			server.Close()
+		}()
+		go func() {
+			logger.Info("Metrics endpoint listening", zap.String("addr", metricsAddr))
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Metrics endpoint stopped", zap.String("error", err.Error()))
+			}
+		}()
+	}
+
+	idColumn := source.Schema().IDColumn
+	var readErr error
+	go func() {
+		defer close(metrics.rowQueue)
+		for {
+			line, err := source.ReadRow(ctx)
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				readErr = err
+				return
+			}
+
+			id := line[idColumn]
+			if skipUntil != "" {
+				if id == skipUntil {
+					skipUntil = ""
+				}
+				continue
+			}
+			if !dryRun {
+				trackPending(fileName, id)
+			}
+			metrics.rowQueue <- line
+		}
+	}()
+
+	var workerWG sync.WaitGroup
+	workerWG.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workerWG.Done()
+			for line := range metrics.rowQueue {
+				measurement, err := source.Parse(line)
+				if err != nil {
+					atomic.AddUint64(&metrics.parseErrors, 1)
+					logger.Error("Parsing CSV row",
+						zap.String("error", err.Error()))
+					if !dryRun {
+						resolvePending([]string{line[idColumn]}, false)
+					}
+					continue
+				}
+
+				if !dryRun {
+					if err := indexMeasurement(bulkProc, indexName, measurement); err != nil {
+						logger.Error("Queueing measurement for bulk index",
+							zap.String("error", err.Error()))
+						resolvePending([]string{measurement.ID}, false)
+						continue
+					}
+				}
+				atomic.AddUint64(&metrics.rowsProcessed, 1)
+			}
+		}()
+	}
+	workerWG.Wait()
+
+	logger.Info("CSV pipeline complete",
+		zap.Uint64("rows_processed", atomic.LoadUint64(&metrics.rowsProcessed)),
+		zap.Uint64("parse_errors", atomic.LoadUint64(&metrics.parseErrors)))
+
+	return readErr
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/olivere/elastic"
+	"go.uber.org/zap"
+
+	"github.com/harsh543/Chicago-Weather-using-ELK/sources"
+)
+
+// sodaPollInterval controls how often the SODA poller checks for new
+// station readings.
+const sodaPollInterval = 5 * time.Minute
+
+// indexMeasurement submits a single WeatherMeasurement to the bulk
+// processor using the same create semantics as the CSV import path.
+func indexMeasurement(bulkProc *elastic.BulkProcessor, indexName string, measurement sources.WeatherMeasurement) error {
+	jsonM, err := json.Marshal(measurement)
+	if err != nil {
+		return err
+	}
+	indexRequest := elastic.NewBulkIndexRequest().
+		Index(indexName).
+		Type("_doc").
+		OpType("create").
+		Id(measurement.ID).
+		Doc(string(jsonM))
+	bulkProc.Add(indexRequest)
+	return nil
+}
+
+// measurementsHandler accepts either a single WeatherMeasurement JSON
+// document or a newline-delimited batch of them and forwards each one to
+// the bulk processor. The whole batch is decoded up front so a malformed
+// line is rejected before anything from it is queued, instead of leaving
+// the client unable to tell how much of a partially-enqueued batch made
+// it in.
+func measurementsHandler(bulkProc *elastic.BulkProcessor, indexName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		defer r.Body.Close()
+
+		measurements, err := decodeMeasurements(r.Body)
+		if err != nil {
+			logger.Error("Decoding measurements from request body",
+				zap.String("error", err.Error()))
+			http.Error(w, fmt.Sprintf("invalid batch: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		for _, measurement := range measurements {
+			if err := indexMeasurement(bulkProc, indexName, measurement); err != nil {
+				logger.Error("Queueing measurement for bulk index",
+					zap.String("error", err.Error()))
+				http.Error(w, "failed to queue measurement", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "{\"indexed\":%d}", len(measurements))
+	}
+}
+
+// decodeMeasurements parses every line of an NDJSON batch (or a single
+// JSON document) before any of it is queued for indexing.
+func decodeMeasurements(body io.Reader) ([]sources.WeatherMeasurement, error) {
+	scanner := bufio.NewScanner(body)
+	measurements := make([]sources.WeatherMeasurement, 0)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		measurement := sources.WeatherMeasurement{}
+		if err := json.Unmarshal(line, &measurement); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		measurements = append(measurements, measurement)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// startIngestServer runs the HTTP ingestion API until ctx is cancelled.
+// It shares the bulk processor (and therefore checkForErrors backpressure)
+// with the rest of the loader.
+func startIngestServer(ctx context.Context, addr string, bulkProc *elastic.BulkProcessor, indexName string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/measurements", measurementsHandler(bulkProc, indexName))
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logger.Info("Ingestion API listening", zap.String("addr", addr))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("Ingestion API stopped",
+			zap.String("error", err.Error()))
+	}
+}
+
+// startSodaPoller periodically fetches new station readings from the
+// Chicago Data Portal SODA API and forwards them through the bulk
+// pipeline, so the index stays current between CSV imports.
+func startSodaPoller(ctx context.Context, endpoint string, bulkProc *elastic.BulkProcessor, indexName string) {
+	if endpoint == "" {
+		logger.Info("SODA poller disabled, no endpoint configured")
+		return
+	}
+
+	ticker := time.NewTicker(sodaPollInterval)
+	defer ticker.Stop()
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	poll := func() {
+		resp, err := client.Get(endpoint)
+		if err != nil {
+			logger.Error("Polling SODA endpoint",
+				zap.String("endpoint", endpoint),
+				zap.String("error", err.Error()))
+			return
+		}
+		defer resp.Body.Close()
+
+		var readings []sources.WeatherMeasurement
+		if err := json.NewDecoder(resp.Body).Decode(&readings); err != nil {
+			logger.Error("Decoding SODA response",
+				zap.String("endpoint", endpoint),
+				zap.String("error", err.Error()))
+			return
+		}
+
+		for _, measurement := range readings {
+			if err := indexMeasurement(bulkProc, indexName, measurement); err != nil {
+				logger.Error("Queueing SODA reading for bulk index",
+					zap.String("error", err.Error()))
+			}
+		}
+		logger.Info("Polled SODA endpoint",
+			zap.String("endpoint", endpoint),
+			zap.Int("readings", len(readings)))
+	}
+
+	poll()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
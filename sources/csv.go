@@ -0,0 +1,174 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CSVSource reads WeatherMeasurements from a CSV export using a Schema to
+// locate each field, so layouts like the Oak Street and Beach Weather
+// Stations exports can share one implementation.
+type CSVSource struct {
+	file     *os.File
+	reader   *csv.Reader
+	schema   Schema
+	stations map[string]GeoPoint
+	loc      *time.Location
+}
+
+// NewCSVSource opens path and prepares it for reading under schema.
+// stations is the station name -> coordinates lookup table used to
+// populate each measurement's geo_point; it may be nil to skip that.
+func NewCSVSource(path string, schema Schema, stations map[string]GeoPoint) (*CSVSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	// Loaded once here rather than per row: LoadLocation re-reads the
+	// zoneinfo database on every call, which is wasteful on the hot path
+	// and, once Parse runs concurrently across pipeline workers, adds a
+	// filesystem lookup contended by every one of them.
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("getting timezone of America/Chicago: %w", err)
+	}
+
+	return &CSVSource{
+		file:     file,
+		reader:   csv.NewReader(bufio.NewReader(file)),
+		schema:   schema,
+		stations: stations,
+		loc:      loc,
+	}, nil
+}
+
+// Next reads and parses the next data row, skipping the header row if
+// the schema identifies one.
+func (s *CSVSource) Next(ctx context.Context) (WeatherMeasurement, error) {
+	line, err := s.ReadRow(ctx)
+	if err != nil {
+		return WeatherMeasurement{}, err
+	}
+	return s.Parse(line)
+}
+
+// Schema returns the column layout this source was opened with, so a
+// caller driving ReadRow/Parse directly (e.g. a parallel pipeline) can
+// inspect columns without re-parsing a row.
+func (s *CSVSource) Schema() Schema {
+	return s.schema
+}
+
+// ReadRow reads the next data row, skipping the header row if the schema
+// identifies one. It does not parse the row, so it can run on its own
+// goroutine while other goroutines call Parse concurrently.
+func (s *CSVSource) ReadRow(ctx context.Context) ([]string, error) {
+	for {
+		line, err := s.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		if line[s.schema.HeaderSkipColumn] == s.schema.HeaderSkipValue {
+			continue
+		}
+		return line, nil
+	}
+}
+
+// Parse builds a WeatherMeasurement from a row previously returned by
+// ReadRow. It only reads from the schema and station lookup table, so it
+// is safe to call concurrently from multiple parser workers.
+func (s *CSVSource) Parse(line []string) (WeatherMeasurement, error) {
+	schema := s.schema
+	measurement := WeatherMeasurement{}
+	measurement.ID = line[schema.IDColumn]
+
+	if schema.StationNameColumn != noColumn {
+		measurement.Station = line[schema.StationNameColumn]
+		if loc, ok := s.stations[measurement.Station]; ok {
+			geoPoint := loc
+			measurement.Location = &geoPoint
+		}
+	}
+
+	// Convert Measurement Timestamp from CST to ISO-8601 format
+	isoTime := fmt.Sprintf("%s %s", line[schema.TimeColumn], time.Now().In(s.loc).Format("-0700 MST"))
+	parsedTime, err := time.Parse("01/02/2006 03:04:05 PM -0700 MST", isoTime)
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("parsing timestamp into time: %w", err)
+	}
+	measurement.Timestamp = parsedTime.Format(time.RFC3339)
+
+	// Convert Temperature from String to Float
+	temp, err := strconv.ParseFloat(line[schema.TemperatureColumn], 64)
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("converting air temperature to float: %w", err)
+	}
+	measurement.TemperatureCelsius = float32(temp)
+	measurement.TemperatureFahrenheit = int32(temp*1.8 + 32.0)
+
+	// Convert Humidity from String to Int32
+	humidity, err := strconv.Atoi(line[schema.HumidityColumn])
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("converting humidity to int: %w", err)
+	}
+	measurement.HumidityPercentage = int32(humidity)
+
+	// Convert Rain Intensity from String to Float
+	ri, err := strconv.ParseFloat(line[schema.RainIntensityColumn], 64)
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("converting rain intensity to float: %w", err)
+	}
+	measurement.RainIntensityMMPerHour = float32(ri)
+	measurement.RainIntensityInchesPerHour = float32(ri * 0.0393701)
+
+	// Convert Interval Rain from String to Float
+	ir, err := strconv.ParseFloat(line[schema.IntervalRainColumn], 64)
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("converting interval rain to float: %w", err)
+	}
+	measurement.RainSinceLastHourMM = float32(ir)
+	measurement.RainSinceLastHourInches = float32(ir * 0.0393701)
+
+	// Convert Precipitation Type from String to Int32
+	pType, err := strconv.Atoi(line[schema.PrecipitationTypeColumn])
+	if err != nil {
+		return WeatherMeasurement{}, fmt.Errorf("converting precipitation type to int: %w", err)
+	}
+	measurement.PrecipitationType = int32(pType)
+
+	// Wind speed, wind direction, and pressure aren't reported by every
+	// station, so a missing column is left at its zero value instead of
+	// failing the row.
+	if schema.WindSpeedColumn != noColumn {
+		if windSpeed, err := strconv.ParseFloat(line[schema.WindSpeedColumn], 64); err == nil {
+			measurement.WindSpeedMPH = float32(windSpeed)
+		}
+	}
+	if schema.WindDirectionColumn != noColumn {
+		if windDirection, err := strconv.ParseFloat(line[schema.WindDirectionColumn], 64); err == nil {
+			measurement.WindDirectionDegrees = float32(windDirection)
+		}
+	}
+	if schema.PressureColumn != noColumn {
+		if pressure, err := strconv.ParseFloat(line[schema.PressureColumn], 64); err == nil {
+			measurement.PressureInHg = float32(pressure)
+		}
+	}
+
+	return measurement, nil
+}
+
+// Close releases the underlying file handle.
+func (s *CSVSource) Close() error {
+	return s.file.Close()
+}
+
+var _ WeatherSource = (*CSVSource)(nil)
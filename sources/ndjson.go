@@ -0,0 +1,55 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// NDJSONSource reads WeatherMeasurements from a file of newline-delimited
+// JSON documents, one measurement per line.
+type NDJSONSource struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+// NewNDJSONSource opens path for NDJSON reading.
+func NewNDJSONSource(path string) (*NDJSONSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return &NDJSONSource{
+		file:    file,
+		scanner: bufio.NewScanner(file),
+	}, nil
+}
+
+// Next decodes the next non-empty line into a WeatherMeasurement.
+func (s *NDJSONSource) Next(ctx context.Context) (WeatherMeasurement, error) {
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		measurement := WeatherMeasurement{}
+		if err := json.Unmarshal(line, &measurement); err != nil {
+			return WeatherMeasurement{}, fmt.Errorf("decoding NDJSON line: %w", err)
+		}
+		return measurement, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return WeatherMeasurement{}, err
+	}
+	return WeatherMeasurement{}, io.EOF
+}
+
+// Close releases the underlying file handle.
+func (s *NDJSONSource) Close() error {
+	return s.file.Close()
+}
+
+var _ WeatherSource = (*NDJSONSource)(nil)
@@ -0,0 +1,112 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WeatherResponse mirrors the shape returned by OpenWeatherMap-style
+// current-conditions endpoints.
+type WeatherResponse struct {
+	Dt   int64 `json:"dt"`
+	Main Main  `json:"main"`
+	Wind Wind  `json:"wind"`
+	Rain Rain  `json:"rain"`
+}
+
+// Main holds the temperature and humidity block of a WeatherResponse.
+type Main struct {
+	Temp     float64 `json:"temp"`
+	Humidity int32   `json:"humidity"`
+}
+
+// Wind holds the wind block of a WeatherResponse.
+type Wind struct {
+	Speed float64 `json:"speed"`
+	Deg   float64 `json:"deg"`
+}
+
+// Rain holds the rolling rain-volume block of a WeatherResponse.
+type Rain struct {
+	OneHour float64 `json:"1h"`
+}
+
+// metersPerSecondToMPH converts OpenWeatherMap's wind speed, reported in
+// meters per second, into the mph unit WeatherMeasurement expects.
+const metersPerSecondToMPH = 2.23694
+
+// OWMSource reads WeatherMeasurements from a file of newline-delimited
+// OpenWeatherMap-style JSON responses, mapping their Main/Wind/Rain shape
+// onto the common WeatherMeasurement document.
+type OWMSource struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+// NewOWMSource opens path for OpenWeatherMap response reading.
+func NewOWMSource(path string) (*OWMSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return &OWMSource{
+		file:    file,
+		scanner: bufio.NewScanner(file),
+	}, nil
+}
+
+// Next decodes the next non-empty line and maps it to a
+// WeatherMeasurement.
+func (s *OWMSource) Next(ctx context.Context) (WeatherMeasurement, error) {
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		response := WeatherResponse{}
+		if err := json.Unmarshal(line, &response); err != nil {
+			return WeatherMeasurement{}, fmt.Errorf("decoding OpenWeatherMap response: %w", err)
+		}
+		return mapWeatherResponse(response), nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return WeatherMeasurement{}, err
+	}
+	return WeatherMeasurement{}, io.EOF
+}
+
+// mapWeatherResponse converts an OpenWeatherMap-style response, whose
+// temperature is reported in Kelvin, into the common WeatherMeasurement
+// shape.
+func mapWeatherResponse(response WeatherResponse) WeatherMeasurement {
+	celsius := response.Main.Temp - 273.15
+
+	measurement := WeatherMeasurement{
+		ID:                 fmt.Sprintf("%d", response.Dt),
+		Timestamp:          time.Unix(response.Dt, 0).UTC().Format(time.RFC3339),
+		TemperatureCelsius: float32(celsius),
+		HumidityPercentage: response.Main.Humidity,
+	}
+	measurement.TemperatureFahrenheit = int32(celsius*1.8 + 32.0)
+	measurement.WindSpeedMPH = float32(response.Wind.Speed * metersPerSecondToMPH)
+	measurement.WindDirectionDegrees = float32(response.Wind.Deg)
+
+	if response.Rain.OneHour > 0 {
+		measurement.RainIntensityMMPerHour = float32(response.Rain.OneHour)
+		measurement.RainIntensityInchesPerHour = float32(response.Rain.OneHour * 0.0393701)
+	}
+
+	return measurement
+}
+
+// Close releases the underlying file handle.
+func (s *OWMSource) Close() error {
+	return s.file.Close()
+}
+
+var _ WeatherSource = (*OWMSource)(nil)
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// noColumn marks a field as absent from a given CSV layout, e.g. a
+// station that doesn't report wind speed.
+const noColumn = -1
+
+// Schema describes where each field of a WeatherMeasurement lives within
+// a CSV layout, so adding support for a new export only requires a new
+// descriptor file under schemas/, not a code change.
+type Schema struct {
+	Name                    string `json:"name"`
+	HeaderSkipColumn        int    `json:"header_skip_column"`
+	HeaderSkipValue         string `json:"header_skip_value"`
+	StationNameColumn       int    `json:"station_name_column"`
+	IDColumn                int    `json:"id_column"`
+	TimeColumn              int    `json:"time_column"`
+	TemperatureColumn       int    `json:"temperature_column"`
+	HumidityColumn          int    `json:"humidity_column"`
+	RainIntensityColumn     int    `json:"rain_intensity_column"`
+	IntervalRainColumn      int    `json:"interval_rain_column"`
+	PrecipitationTypeColumn int    `json:"precipitation_type_column"`
+	WindSpeedColumn         int    `json:"wind_speed_column"`
+	WindDirectionColumn     int    `json:"wind_direction_column"`
+	PressureColumn          int    `json:"pressure_column"`
+}
+
+// SchemaDir is where schema descriptor files are looked up by name.
+const SchemaDir = "schemas"
+
+// LoadSchema reads the descriptor for the named CSV layout, e.g. "oak"
+// or "beach", from SchemaDir.
+func LoadSchema(name string) (Schema, error) {
+	path := filepath.Join(SchemaDir, name+".json")
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Schema{}, fmt.Errorf("reading schema %s: %w", path, err)
+	}
+
+	schema := Schema{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return Schema{}, fmt.Errorf("parsing schema %s: %w", path, err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,47 @@
+// Package sources provides pluggable readers of weather measurements, so
+// the loader can ingest heterogeneous datasets (CSV exports, NDJSON
+// dumps, third-party APIs) into the same chicago-weather index without a
+// bespoke parser for each one.
+package sources
+
+import "context"
+
+// GeoPoint is an Elasticsearch geo_point value, populated from a
+// station's coordinates so docs can be placed on a Kibana map.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// WeatherMeasurement is the common document shape indexed into
+// Elasticsearch, regardless of which WeatherSource produced it.
+type WeatherMeasurement struct {
+	ID                         string    `json:"id"`
+	Timestamp                  string    `json:"date"`
+	Station                    string    `json:"station_name,omitempty"`
+	Location                   *GeoPoint `json:"location,omitempty"`
+	TemperatureCelsius         float32   `json:"temperature_celsius"`
+	TemperatureFahrenheit      int32     `json:"temperature_fahrenheit"`
+	HumidityPercentage         int32     `json:"humidity_percentage"`
+	DewPointCelsius            float32   `json:"dew_point_celsius,omitempty"`
+	RainIntensityMMPerHour     float32   `json:"rain_intensity_mm_per_hour"`
+	RainIntensityInchesPerHour float32   `json:"rain_intensity_inches_per_hour"`
+	RainSinceLastHourMM        float32   `json:"rain_since_last_hour_mm"`
+	RainSinceLastHourInches    float32   `json:"rain_since_last_hour_inches"`
+	PrecipitationType          int32     `json:"precipitation_type"`
+	WindSpeedMPH               float32   `json:"wind_speed_mph,omitempty"`
+	WindDirectionDegrees       float32   `json:"wind_direction_degrees,omitempty"`
+	WindGustMPH                float32   `json:"wind_gust_mph,omitempty"`
+	PressureInHg               float32   `json:"pressure_in_hg,omitempty"`
+	UVIndex                    float32   `json:"uv_index,omitempty"`
+	CloudCoverPercentage       int32     `json:"cloud_cover_percentage,omitempty"`
+	VisibilityMiles            float32   `json:"visibility_miles,omitempty"`
+}
+
+// WeatherSource produces a stream of WeatherMeasurements. Next returns
+// io.EOF once the source is exhausted. Implementations must be safe to
+// Close even if Next has never been called.
+type WeatherSource interface {
+	Next(ctx context.Context) (WeatherMeasurement, error)
+	Close() error
+}
@@ -0,0 +1,25 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// StationsFile is the station name -> coordinates lookup table loaded at
+// startup so every indexed measurement can carry a geo_point.
+const StationsFile = "stations.json"
+
+// LoadStations reads the station metadata lookup table from StationsFile.
+func LoadStations() (map[string]GeoPoint, error) {
+	raw, err := ioutil.ReadFile(StationsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", StationsFile, err)
+	}
+
+	stations := map[string]GeoPoint{}
+	if err := json.Unmarshal(raw, &stations); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", StationsFile, err)
+	}
+	return stations, nil
+}